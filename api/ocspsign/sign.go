@@ -0,0 +1,90 @@
+// Package ocspsign implements the HTTP handler for signing OCSP
+// responses over the CFSSL JSON API.
+package ocspsign
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/cloudflare/cfssl/api"
+	cferr "github.com/cloudflare/cfssl/errors"
+	"github.com/cloudflare/cfssl/helpers"
+	"github.com/cloudflare/cfssl/log"
+	"github.com/cloudflare/cfssl/ocsp"
+)
+
+// jsonSignRequest is the JSON representation of an OCSP sign request: a
+// PEM-encoded certificate and the status CFSSL should sign a response
+// for. RevokedAt may be the literal string "now", or an RFC 3339
+// timestamp; a malformed value is rejected whatever the Status, but the
+// signer only puts it into a "revoked" response.
+type jsonSignRequest struct {
+	Certificate string `json:"certificate"`
+	Status      string `json:"status"`
+	Reason      int    `json:"reason"`
+	RevokedAt   string `json:"revoked_at"`
+}
+
+// Handler signs OCSP responses over the CFSSL JSON API.
+type Handler struct {
+	signer ocsp.Signer
+}
+
+// NewHandler returns a new http.Handler that signs OCSP responses using
+// signer.
+func NewHandler(signer ocsp.Signer) http.Handler {
+	return api.HTTPHandler{
+		Handler: &Handler{signer: signer},
+		Methods: []string{"POST"},
+	}
+}
+
+// Handle implements api.Handler. It parses a jsonSignRequest body, signs
+// an OCSP response for the enclosed certificate, and returns the
+// base64-encoded response inside CFSSL's standard API envelope.
+func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return cferr.Wrap(cferr.OCSPError, cferr.ReadFailed, err)
+	}
+	r.Body.Close()
+
+	var jsonReq jsonSignRequest
+	if err := json.Unmarshal(body, &jsonReq); err != nil {
+		return cferr.Wrap(cferr.OCSPError, cferr.ParseFailed, err)
+	}
+
+	cert, err := helpers.ParseCertificatePEM([]byte(jsonReq.Certificate))
+	if err != nil {
+		return err
+	}
+
+	req := ocsp.SignRequest{
+		Certificate: cert,
+		Status:      jsonReq.Status,
+		Reason:      jsonReq.Reason,
+	}
+
+	switch jsonReq.RevokedAt {
+	case "":
+	case "now":
+		req.RevokedAt = time.Now()
+	default:
+		req.RevokedAt, err = time.Parse(time.RFC3339, jsonReq.RevokedAt)
+		if err != nil {
+			return cferr.Wrap(cferr.OCSPError, cferr.ParseFailed, err)
+		}
+	}
+
+	response, err := h.signer.Sign(req)
+	if err != nil {
+		log.Warningf("failed to sign OCSP response: %v", err)
+		return err
+	}
+
+	result := map[string]string{"response": base64.StdEncoding.EncodeToString(response)}
+	return api.SendResponse(w, result)
+}
@@ -0,0 +1,152 @@
+package ocspsign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cfssl/ocsp"
+)
+
+// stubSigner is an ocsp.Signer test double that records the SignRequest
+// it was called with, or returns a fixed error.
+type stubSigner struct {
+	req SignRequestRecorder
+	der []byte
+	err error
+}
+
+// SignRequestRecorder captures a Sign call so a test can assert on it.
+type SignRequestRecorder struct {
+	req *ocsp.SignRequest
+}
+
+func (s *stubSigner) Sign(req ocsp.SignRequest) ([]byte, error) {
+	s.req.req = &req
+	return s.der, s.err
+}
+
+func testCertPEM(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate a test key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test leaf"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create a test certificate: %v", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func handle(t *testing.T, signer ocsp.Signer, body string) (*httptest.ResponseRecorder, error) {
+	t.Helper()
+	h := &Handler{signer: signer}
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/cfssl/ocspsign", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	return w, h.Handle(w, r)
+}
+
+func TestHandleSignsGoodStatus(t *testing.T) {
+	signer := &stubSigner{der: []byte("ocsp-response")}
+	body := `{"certificate": ` + quote(testCertPEM(t)) + `, "status": "good"}`
+
+	w, err := handle(t, signer, body)
+	if err != nil {
+		t.Fatalf("Handle returned an error: %v", err)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("status code = %d, want 200", w.Code)
+	}
+	if signer.req.req == nil {
+		t.Fatal("signer was never called")
+	}
+	if signer.req.req.Status != "good" {
+		t.Errorf("SignRequest.Status = %q, want good", signer.req.req.Status)
+	}
+}
+
+func TestHandleRevokedAtNow(t *testing.T) {
+	signer := &stubSigner{der: []byte("ocsp-response")}
+	body := `{"certificate": ` + quote(testCertPEM(t)) + `, "status": "revoked", "revoked_at": "now"}`
+
+	before := time.Now()
+	if _, err := handle(t, signer, body); err != nil {
+		t.Fatalf("Handle returned an error: %v", err)
+	}
+	after := time.Now()
+
+	got := signer.req.req.RevokedAt
+	if got.Before(before) || got.After(after) {
+		t.Errorf("RevokedAt = %v, want between %v and %v", got, before, after)
+	}
+}
+
+func TestHandleRevokedAtRFC3339(t *testing.T) {
+	signer := &stubSigner{der: []byte("ocsp-response")}
+	body := `{"certificate": ` + quote(testCertPEM(t)) + `, "status": "revoked", "revoked_at": "2026-01-01T00:00:00Z"}`
+
+	if _, err := handle(t, signer, body); err != nil {
+		t.Fatalf("Handle returned an error: %v", err)
+	}
+
+	want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := signer.req.req.RevokedAt; !got.Equal(want) {
+		t.Errorf("RevokedAt = %v, want %v", got, want)
+	}
+}
+
+func TestHandleMalformedJSON(t *testing.T) {
+	if _, err := handle(t, &stubSigner{}, "not json"); err == nil {
+		t.Fatal("expected an error for a malformed JSON body, got nil")
+	}
+}
+
+func TestHandleBadCertificatePEM(t *testing.T) {
+	body := `{"certificate": "not a pem certificate", "status": "good"}`
+	if _, err := handle(t, &stubSigner{}, body); err == nil {
+		t.Fatal("expected an error for a bad certificate PEM, got nil")
+	}
+}
+
+func TestHandleBadRevokedAt(t *testing.T) {
+	body := `{"certificate": ` + quote(testCertPEM(t)) + `, "status": "revoked", "revoked_at": "not-a-timestamp"}`
+	if _, err := handle(t, &stubSigner{}, body); err == nil {
+		t.Fatal("expected an error for a malformed revoked_at, got nil")
+	}
+}
+
+func TestHandleSignerError(t *testing.T) {
+	signer := &stubSigner{err: errors.New("signing backend unavailable")}
+	body := `{"certificate": ` + quote(testCertPEM(t)) + `, "status": "good"}`
+
+	if _, err := handle(t, signer, body); err == nil {
+		t.Fatal("expected Handle to propagate the signer's error, got nil")
+	}
+}
+
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,100 @@
+package ocsp
+
+import (
+	"crypto/x509"
+	"math/big"
+	"testing"
+)
+
+// stubSigner is a Signer test double that returns a fixed response or
+// error, so MultiSigner dispatch can be tested without real certificates
+// or keys.
+type stubSigner struct {
+	der []byte
+	err error
+}
+
+func (s *stubSigner) Sign(req SignRequest) ([]byte, error) {
+	return s.der, s.err
+}
+
+func TestMultiSignerDispatchesByIssuer(t *testing.T) {
+	issuerA := &x509.Certificate{
+		RawSubject:              []byte("issuer-a-subject"),
+		RawSubjectPublicKeyInfo: []byte("issuer-a-spki"),
+	}
+	issuerB := &x509.Certificate{
+		RawSubject:              []byte("issuer-b-subject"),
+		RawSubjectPublicKeyInfo: []byte("issuer-b-spki"),
+	}
+
+	signerA := &stubSigner{der: []byte("response-a")}
+	signerB := &stubSigner{der: []byte("response-b")}
+
+	multi := NewMultiSigner()
+	multi.AddSigner(issuerA, signerA)
+	multi.AddSigner(issuerB, signerB)
+
+	cert := &x509.Certificate{RawIssuer: issuerB.RawSubject, SerialNumber: big.NewInt(1)}
+
+	der, err := multi.Sign(SignRequest{Certificate: cert})
+	if err != nil {
+		t.Fatalf("Sign returned an error: %v", err)
+	}
+	if string(der) != "response-b" {
+		t.Errorf("Sign dispatched to the wrong signer: got %q, want %q", der, "response-b")
+	}
+}
+
+func TestMultiSignerUnknownIssuer(t *testing.T) {
+	multi := NewMultiSigner()
+	cert := &x509.Certificate{RawIssuer: []byte("unregistered"), SerialNumber: big.NewInt(1)}
+
+	if _, err := multi.Sign(SignRequest{Certificate: cert}); err == nil {
+		t.Fatal("expected an error for an unregistered issuer, got nil")
+	}
+}
+
+func TestMultiSignerNoCertificate(t *testing.T) {
+	multi := NewMultiSigner()
+
+	if _, err := multi.Sign(SignRequest{}); err == nil {
+		t.Fatal("expected an error for a SignRequest with no certificate, got nil")
+	}
+}
+
+func TestMultiSignerByKeyHash(t *testing.T) {
+	issuer := &x509.Certificate{
+		RawSubject:              []byte("issuer-subject"),
+		RawSubjectPublicKeyInfo: []byte("issuer-spki"),
+	}
+	signer := &stubSigner{der: []byte("response")}
+
+	multi := NewMultiSigner()
+	multi.AddSigner(issuer, signer)
+
+	hash := []byte(keyHash(issuer))
+
+	gotIssuer, ok := multi.IssuerByKeyHash(hash)
+	if !ok || gotIssuer != issuer {
+		t.Fatal("IssuerByKeyHash did not return the registered issuer")
+	}
+
+	gotSigner, ok := multi.SignerByKeyHash(hash)
+	if !ok || gotSigner != Signer(signer) {
+		t.Fatal("SignerByKeyHash did not return the registered signer")
+	}
+
+	if _, ok := multi.SignerByKeyHash([]byte("unregistered-hash")); ok {
+		t.Fatal("SignerByKeyHash reported a match for an unregistered hash")
+	}
+}
+
+func TestNewMultiSignerFromConfigRejectsYAML(t *testing.T) {
+	if _, err := NewMultiSignerFromConfig("signers.yaml"); err == nil {
+		t.Fatal("expected an error for a .yaml config file, got nil")
+	}
+	if _, err := NewMultiSignerFromConfig("signers.yml"); err == nil {
+		t.Fatal("expected an error for a .yml config file, got nil")
+	}
+}
@@ -15,6 +15,10 @@ import (
 	"golang.org/x/crypto/ocsp"
 )
 
+// errIssuerMismatch is returned when a SignRequest's certificate was not
+// issued by this signer's issuer.
+var errIssuerMismatch = errors.New("certificate's issuer does not match responder's issuer")
+
 var statusCode = map[string]int{
 	"good":    ocsp.Good,
 	"revoked": ocsp.Revoked,
@@ -34,7 +38,8 @@ type Signer interface {
 	Sign(req SignRequest) ([]byte, error)
 }
 
-// TODO
+// StandardSigner is a Signer that signs OCSP responses for a single
+// issuing CA with a fixed responder certificate and key.
 type StandardSigner struct {
 	issuer    *x509.Certificate
 	responder *x509.Certificate
@@ -51,7 +56,7 @@ func NewStandardSignerFromFile(issuerFile, responderFile, keyFile string, interv
 		return nil, err
 	}
 	log.Debug("Loading responder cert: ", responderFile)
-	responderBytes, err := ioutil.ReadFile(issuerFile)
+	responderBytes, err := ioutil.ReadFile(responderFile)
 	if err != nil {
 		return nil, err
 	}
@@ -93,15 +98,17 @@ func NewSigner(issuer, responder *x509.Certificate, key crypto.Signer, interval
 
 func (s StandardSigner) Sign(req SignRequest) ([]byte, error) {
 	if req.Certificate == nil {
-		return nil, errors.New("TODO") // XXX
+		// errNoCertificate is already a *cferr.Error; cferr.Wrap panics
+		// if asked to wrap one.
+		return nil, errNoCertificate
 	}
 
 	// Verify that req.Certificate is issued under s.issuer
 	if bytes.Compare(req.Certificate.RawIssuer, s.issuer.RawSubject) != 0 {
-		return nil, errors.New("TODO") // XXX
+		return nil, cferr.Wrap(cferr.OCSPError, cferr.IssuerMismatch, errIssuerMismatch)
 	}
-	if req.Certificate.CheckSignatureFrom(s.issuer) != nil {
-		return nil, errors.New("TODO") // XXX
+	if err := req.Certificate.CheckSignatureFrom(s.issuer); err != nil {
+		return nil, cferr.Wrap(cferr.OCSPError, cferr.IssuerMismatch, err)
 	}
 
 	// Round thisUpdate times to the nearest hour
@@ -110,7 +117,7 @@ func (s StandardSigner) Sign(req SignRequest) ([]byte, error) {
 
 	status, ok := statusCode[req.Status]
 	if !ok {
-		return nil, errors.New("TODO") // XXX
+		return nil, cferr.Wrap(cferr.OCSPError, cferr.InvalidStatus, errors.New("unknown OCSP status: "+req.Status))
 	}
 
 	template := ocsp.Response{
@@ -0,0 +1,173 @@
+package ocsp
+
+import (
+	"crypto/x509"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudflare/cfssl/log"
+	"golang.org/x/crypto/ocsp"
+)
+
+// CertificateStatus names a single certificate a Producer should keep a
+// signed OCSP response available for. Its revocation status, reason, and
+// RevokedAt time are not carried here -- they are looked up fresh from
+// Producer's StatusSource on every refresh, so a certificate revoked
+// after it was added to the set is picked up without re-registering it.
+type CertificateStatus struct {
+	Issuer *x509.Certificate
+	Serial *big.Int
+}
+
+func responseKey(issuer *x509.Certificate, serial *big.Int) string {
+	return keyHash(issuer) + ":" + serial.String()
+}
+
+// ResponseStore persists pre-signed OCSP responses keyed by issuer and
+// serial, so a read-only responder fleet can serve static files instead
+// of signing live on every request.
+type ResponseStore interface {
+	Put(issuer *x509.Certificate, serial *big.Int, der []byte) error
+	Get(issuer *x509.Certificate, serial *big.Int) ([]byte, error)
+}
+
+// ProducerStats are Prometheus-style counters tracking a Producer's
+// signing activity. Fields are updated with sync/atomic and may be read
+// directly.
+type ProducerStats struct {
+	Signed uint64
+	Failed uint64
+	Stale  uint64
+}
+
+// Producer keeps a ResponseStore populated with freshly signed OCSP
+// responses for a fixed set of certificates, so a read-only responder
+// fleet -- the standard high-availability OCSP deployment pattern -- can
+// serve static files instead of signing live on every request.
+type Producer struct {
+	Signer        Signer
+	Certs         CertificateSource
+	Source        StatusSource
+	Store         ResponseStore
+	RefreshWindow time.Duration
+
+	Stats ProducerStats
+
+	mu         sync.Mutex
+	nextUpdate map[string]time.Time
+}
+
+// NewProducer constructs a Producer that signs with signer, resolves
+// certificates via certs, looks up revocation status via source, and
+// writes responses to store. refreshWindow is how far ahead of a
+// response's NextUpdate the background refresh loop started by Run
+// re-signs it.
+func NewProducer(signer Signer, certs CertificateSource, source StatusSource, store ResponseStore, refreshWindow time.Duration) *Producer {
+	return &Producer{
+		Signer:        signer,
+		Certs:         certs,
+		Source:        source,
+		Store:         store,
+		RefreshWindow: refreshWindow,
+		nextUpdate:    make(map[string]time.Time),
+	}
+}
+
+// Produce concurrently signs a response for every status in statuses
+// that is due for a refresh and writes it to Store.
+func (p *Producer) Produce(statuses []CertificateStatus) {
+	var wg sync.WaitGroup
+	for _, status := range statuses {
+		status := status
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.refresh(status)
+		}()
+	}
+	wg.Wait()
+}
+
+// refresh re-signs status's response if it has never been signed or is
+// within RefreshWindow of its recorded NextUpdate. The signer rounds
+// thisUpdate to the hour, so skipping refreshes that aren't due keeps it
+// from churning out identical responses.
+func (p *Producer) refresh(status CertificateStatus) {
+	key := responseKey(status.Issuer, status.Serial)
+
+	p.mu.Lock()
+	next, signed := p.nextUpdate[key]
+	p.mu.Unlock()
+
+	if signed {
+		if time.Now().After(next) {
+			atomic.AddUint64(&p.Stats.Stale, 1)
+		} else if time.Now().Before(next.Add(-p.RefreshWindow)) {
+			return
+		}
+	}
+
+	cert, err := p.Certs.Certificate(status.Serial, status.Issuer)
+	if err != nil {
+		atomic.AddUint64(&p.Stats.Failed, 1)
+		log.Warning("failed to look up certificate for OCSP response: ", err)
+		return
+	}
+
+	certStatus, reason, revokedAt, err := p.Source.Lookup(status.Serial, status.Issuer)
+	if err != nil {
+		atomic.AddUint64(&p.Stats.Failed, 1)
+		log.Warning("failed to look up OCSP status for certificate: ", err)
+		return
+	}
+
+	der, err := p.Signer.Sign(SignRequest{
+		Certificate: cert,
+		Status:      certStatus,
+		Reason:      reason,
+		RevokedAt:   revokedAt,
+	})
+	if err != nil {
+		atomic.AddUint64(&p.Stats.Failed, 1)
+		log.Warning("failed to sign OCSP response: ", err)
+		return
+	}
+
+	if err := p.Store.Put(status.Issuer, status.Serial, der); err != nil {
+		atomic.AddUint64(&p.Stats.Failed, 1)
+		log.Warning("failed to write OCSP response: ", err)
+		return
+	}
+
+	resp, err := ocsp.ParseResponse(der, nil)
+	if err != nil {
+		log.Warning("produced an unparseable OCSP response: ", err)
+		return
+	}
+
+	p.mu.Lock()
+	p.nextUpdate[key] = resp.NextUpdate
+	p.mu.Unlock()
+
+	atomic.AddUint64(&p.Stats.Signed, 1)
+}
+
+// Run starts a background goroutine that calls Produce(statuses) on
+// every tick of interval, until stop is closed.
+func (p *Producer) Run(statuses []CertificateStatus, interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.Produce(statuses)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,195 @@
+package ocsp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// testIssuer returns a throwaway self-signed certificate, usable as both
+// an issuer and a responder certificate for signing test OCSP responses.
+func testIssuer(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate a test key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test issuer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create a test certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse the test certificate: %v", err)
+	}
+	return cert, key
+}
+
+// signingSigner is a Signer that signs real, parseable OCSP responses
+// using a throwaway issuer/key, so Producer.refresh's NextUpdate bookkeeping
+// can be exercised end-to-end.
+type signingSigner struct {
+	issuer *x509.Certificate
+	key    *ecdsa.PrivateKey
+	calls  int
+}
+
+func (s *signingSigner) Sign(req SignRequest) ([]byte, error) {
+	s.calls++
+	status, ok := statusCode[req.Status]
+	if !ok {
+		status = ocsp.Good
+	}
+	resp := ocsp.Response{
+		Status:       status,
+		SerialNumber: req.Certificate.SerialNumber,
+		ThisUpdate:   time.Now().Round(time.Hour),
+		NextUpdate:   time.Now().Round(time.Hour).Add(time.Hour),
+		Certificate:  s.issuer,
+	}
+	return ocsp.CreateResponse(s.issuer, s.issuer, resp, s.key)
+}
+
+// stubCertSource is a CertificateSource that always returns the same
+// certificate.
+type stubCertSource struct {
+	cert *x509.Certificate
+}
+
+func (s stubCertSource) Certificate(serial *big.Int, issuer *x509.Certificate) (*x509.Certificate, error) {
+	return s.cert, nil
+}
+
+// stubStatusSource is a StatusSource that always returns the same status.
+type stubStatusSource struct {
+	status string
+}
+
+func (s stubStatusSource) Lookup(serial *big.Int, issuer *x509.Certificate) (string, int, time.Time, error) {
+	return s.status, 0, time.Time{}, nil
+}
+
+func TestProducerRefreshSignsAndStores(t *testing.T) {
+	issuer, key := testIssuer(t)
+	signer := &signingSigner{issuer: issuer, key: key}
+	store := NewMapStore()
+
+	p := NewProducer(signer, stubCertSource{cert: issuer}, stubStatusSource{status: "good"}, store, time.Hour)
+	status := CertificateStatus{Issuer: issuer, Serial: issuer.SerialNumber}
+
+	p.refresh(status)
+
+	if signer.calls != 1 {
+		t.Fatalf("Signer.Sign was called %d times, want 1", signer.calls)
+	}
+	if p.Stats.Signed != 1 {
+		t.Errorf("Stats.Signed = %d, want 1", p.Stats.Signed)
+	}
+	if p.Stats.Failed != 0 {
+		t.Errorf("Stats.Failed = %d, want 0", p.Stats.Failed)
+	}
+
+	if _, err := store.Get(issuer, issuer.SerialNumber); err != nil {
+		t.Errorf("Store.Get failed after a successful refresh: %v", err)
+	}
+}
+
+func TestProducerRefreshSkipsWhenNotDue(t *testing.T) {
+	issuer, key := testIssuer(t)
+	signer := &signingSigner{issuer: issuer, key: key}
+	store := NewMapStore()
+
+	p := NewProducer(signer, stubCertSource{cert: issuer}, stubStatusSource{status: "good"}, store, time.Hour)
+	status := CertificateStatus{Issuer: issuer, Serial: issuer.SerialNumber}
+
+	nextKey := responseKey(issuer, issuer.SerialNumber)
+	p.nextUpdate[nextKey] = time.Now().Add(24 * time.Hour)
+
+	p.refresh(status)
+
+	if signer.calls != 0 {
+		t.Errorf("Signer.Sign was called %d times, want 0 (refresh isn't due yet)", signer.calls)
+	}
+}
+
+func TestProducerRefreshResignsWhenStale(t *testing.T) {
+	issuer, key := testIssuer(t)
+	signer := &signingSigner{issuer: issuer, key: key}
+	store := NewMapStore()
+
+	p := NewProducer(signer, stubCertSource{cert: issuer}, stubStatusSource{status: "good"}, store, time.Hour)
+	status := CertificateStatus{Issuer: issuer, Serial: issuer.SerialNumber}
+
+	nextKey := responseKey(issuer, issuer.SerialNumber)
+	p.nextUpdate[nextKey] = time.Now().Add(-time.Hour)
+
+	p.refresh(status)
+
+	if signer.calls != 1 {
+		t.Fatalf("Signer.Sign was called %d times, want 1 (a stale response must be re-signed)", signer.calls)
+	}
+	if p.Stats.Stale != 1 {
+		t.Errorf("Stats.Stale = %d, want 1", p.Stats.Stale)
+	}
+	if p.Stats.Signed != 1 {
+		t.Errorf("Stats.Signed = %d, want 1", p.Stats.Signed)
+	}
+}
+
+func TestMapStoreRoundTrip(t *testing.T) {
+	issuer, _ := testIssuer(t)
+	store := NewMapStore()
+
+	if _, err := store.Get(issuer, big.NewInt(1)); err == nil {
+		t.Fatal("Get succeeded before any Put")
+	}
+
+	if err := store.Put(issuer, big.NewInt(1), []byte("response")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	der, err := store.Get(issuer, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("Get failed after Put: %v", err)
+	}
+	if string(der) != "response" {
+		t.Errorf("Get returned %q, want %q", der, "response")
+	}
+}
+
+func TestDirectoryStoreRoundTrip(t *testing.T) {
+	issuer, _ := testIssuer(t)
+	store := DirectoryStore{Dir: t.TempDir()}
+
+	if _, err := store.Get(issuer, big.NewInt(1)); err == nil {
+		t.Fatal("Get succeeded before any Put")
+	}
+
+	if err := store.Put(issuer, big.NewInt(1), []byte("response")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	der, err := store.Get(issuer, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("Get failed after Put: %v", err)
+	}
+	if string(der) != "response" {
+		t.Errorf("Get returned %q, want %q", der, "response")
+	}
+}
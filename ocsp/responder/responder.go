@@ -0,0 +1,222 @@
+// Package responder implements the HTTP wire protocol for an OCSP
+// responder, as described in RFC 6960: base64-in-path GET requests and
+// POST requests carrying a DER-encoded OCSPRequest body, answered with an
+// application/ocsp-response. This is the "server" half of package ocsp,
+// which knows how to sign a response but does not expose one over HTTP.
+package responder
+
+import (
+	"crypto"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cfssl/log"
+	cfocsp "github.com/cloudflare/cfssl/ocsp"
+	"golang.org/x/crypto/ocsp"
+)
+
+// errUnknownIssuer is returned by SignerSource.Response when the
+// request's CertID doesn't match any issuer this responder serves.
+// ServeHTTP maps it to the RFC 6960 unauthorized status; any other
+// error from a Source is treated as transient and mapped to tryLater.
+var errUnknownIssuer = errors.New("responder: no signer registered for this issuer")
+
+// Source answers an RFC 6960 request with the DER bytes of a signed OCSP
+// response, or an error if none can be produced.
+type Source interface {
+	Response(req *ocsp.Request) ([]byte, error)
+}
+
+// SignerSource adapts a MultiSigner into a Source: it maps a request's
+// CertID to the registered issuer and Signer by issuerKeyHash, fetches
+// the actual certificate for the requested serial from Certs, and
+// delegates to Signer.Sign.
+type SignerSource struct {
+	Signer *cfocsp.MultiSigner
+	Certs  cfocsp.CertificateSource
+}
+
+// Response implements Source.
+func (s SignerSource) Response(req *ocsp.Request) ([]byte, error) {
+	if req.HashAlgorithm != crypto.SHA1 {
+		return nil, errUnknownIssuer
+	}
+
+	issuer, ok := s.Signer.IssuerByKeyHash(req.IssuerKeyHash)
+	if !ok {
+		return nil, errUnknownIssuer
+	}
+
+	signer, ok := s.Signer.SignerByKeyHash(req.IssuerKeyHash)
+	if !ok {
+		return nil, errUnknownIssuer
+	}
+
+	cert, err := s.Certs.Certificate(req.SerialNumber, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	return signer.Sign(cfocsp.SignRequest{Certificate: cert})
+}
+
+// cacheEntry is a previously signed response, kept around until its
+// NextUpdate so a Responder doesn't re-sign on every request for the
+// same certificate.
+type cacheEntry struct {
+	der        []byte
+	thisUpdate time.Time
+	nextUpdate time.Time
+}
+
+// Responder is an http.Handler that answers OCSP requests by consulting
+// a Source, caching successful responses until their NextUpdate.
+type Responder struct {
+	Source Source
+
+	mu    sync.RWMutex
+	cache map[string]cacheEntry
+}
+
+// NewResponder constructs a Responder that answers requests from source.
+func NewResponder(source Source) *Responder {
+	return &Responder{Source: source, cache: make(map[string]cacheEntry)}
+}
+
+// ServeHTTP implements http.Handler.
+func (rs *Responder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	der, ok := requestDER(r)
+	if !ok {
+		writeError(w, ocsp.Malformed)
+		return
+	}
+
+	ocspReq, err := ocsp.ParseRequest(der)
+	if err != nil {
+		log.Debug("Failed to parse OCSP request: ", err)
+		writeError(w, ocsp.Malformed)
+		return
+	}
+
+	key := requestCacheKey(ocspReq)
+
+	if entry, ok := rs.cached(key); ok {
+		writeResponse(w, entry)
+		return
+	}
+
+	respDER, err := rs.Source.Response(ocspReq)
+	if err != nil {
+		log.Debug("Failed to produce OCSP response: ", err)
+		if errors.Is(err, errUnknownIssuer) {
+			writeError(w, ocsp.Unauthorized)
+		} else {
+			// The request was well-formed and for an issuer we serve;
+			// whatever failed (signing, a backend lookup) is presumed
+			// transient.
+			writeError(w, ocsp.TryLater)
+		}
+		return
+	}
+
+	resp, err := ocsp.ParseResponse(respDER, nil)
+	if err != nil {
+		log.Debug("Produced an unparseable OCSP response: ", err)
+		writeError(w, ocsp.InternalError)
+		return
+	}
+
+	entry := cacheEntry{der: respDER, thisUpdate: resp.ThisUpdate, nextUpdate: resp.NextUpdate}
+	rs.store(key, entry)
+	writeResponse(w, entry)
+}
+
+// requestDER extracts the DER-encoded OCSPRequest from r: base64, path
+// encoded for GET, or a raw application/ocsp-request body for POST.
+func requestDER(r *http.Request) ([]byte, bool) {
+	switch r.Method {
+	case http.MethodGet:
+		encoded := strings.TrimPrefix(r.URL.Path, "/")
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, false
+		}
+		return der, true
+	case http.MethodPost:
+		if r.Header.Get("Content-Type") != "application/ocsp-request" {
+			return nil, false
+		}
+		der, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, false
+		}
+		return der, true
+	default:
+		return nil, false
+	}
+}
+
+func requestCacheKey(req *ocsp.Request) string {
+	return string(req.IssuerKeyHash) + ":" + req.SerialNumber.String()
+}
+
+func (rs *Responder) cached(key string) (cacheEntry, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	entry, ok := rs.cache[key]
+	if !ok || !time.Now().Before(entry.nextUpdate) {
+		return cacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+func (rs *Responder) store(key string, entry cacheEntry) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.cache[key] = entry
+}
+
+func writeResponse(w http.ResponseWriter, entry cacheEntry) {
+	maxAge := int(entry.nextUpdate.Sub(time.Now()).Seconds())
+	if maxAge < 0 {
+		maxAge = 0
+	}
+
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	w.Header().Set("Last-Modified", entry.thisUpdate.Format(http.TimeFormat))
+	w.Header().Set("Expires", entry.nextUpdate.Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d, public, no-transform, must-revalidate", maxAge))
+	w.Header().Set("ETag", strconv.Quote(fmt.Sprintf("%x", entry.der)))
+	w.Write(entry.der)
+}
+
+// asn1OCSPResponse mirrors the OCSPResponse ASN.1 SEQUENCE with no
+// responseBytes, the wire format for an error response: RFC 6960 §4.2.1
+// only populates responseBytes on a successful response.
+type asn1OCSPResponse struct {
+	Status asn1.Enumerated
+}
+
+func writeError(w http.ResponseWriter, status ocsp.ResponseStatus) {
+	der, err := asn1.Marshal(asn1OCSPResponse{Status: asn1.Enumerated(status)})
+	if err != nil {
+		// This can only fail if asn1.Marshal itself is broken; there is
+		// nothing more specific to report to the client.
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	w.Write(der)
+}
@@ -0,0 +1,206 @@
+package responder
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// stubSource is a Source test double that returns a fixed response or
+// error, so Responder can be tested without a real signing backend.
+type stubSource struct {
+	der []byte
+	err error
+}
+
+func (s stubSource) Response(req *ocsp.Request) ([]byte, error) {
+	return s.der, s.err
+}
+
+// countingSource wraps a stubSource and counts calls, so tests can assert
+// the Responder's cache is actually used.
+type countingSource struct {
+	stubSource
+	calls int
+}
+
+func (s *countingSource) Response(req *ocsp.Request) ([]byte, error) {
+	s.calls++
+	return s.stubSource.Response(req)
+}
+
+func testRequest(t *testing.T) []byte {
+	t.Helper()
+	req := &ocsp.Request{
+		HashAlgorithm:  crypto.SHA1,
+		IssuerNameHash: bytes.Repeat([]byte{0x01}, 20),
+		IssuerKeyHash:  bytes.Repeat([]byte{0x02}, 20),
+		SerialNumber:   big.NewInt(1),
+	}
+	der, err := req.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal a test OCSP request: %v", err)
+	}
+	return der
+}
+
+func doRequest(t *testing.T, rs *Responder, method string) *httptest.ResponseRecorder {
+	t.Helper()
+	der := testRequest(t)
+
+	var r *http.Request
+	if method == http.MethodGet {
+		r = httptest.NewRequest(http.MethodGet, "/"+base64.StdEncoding.EncodeToString(der), nil)
+	} else {
+		r = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(der))
+		r.Header.Set("Content-Type", "application/ocsp-request")
+	}
+
+	w := httptest.NewRecorder()
+	rs.ServeHTTP(w, r)
+	return w
+}
+
+// responseStatus parses w's body as an OCSP response and returns its
+// status, translating the error ParseResponse returns for a non-success
+// response back into the ResponseStatus it encodes.
+func responseStatus(t *testing.T, w *httptest.ResponseRecorder) ocsp.ResponseStatus {
+	t.Helper()
+	if _, err := ocsp.ParseResponse(w.Body.Bytes(), nil); err == nil {
+		return ocsp.Success
+	} else if respErr, ok := err.(ocsp.ResponseError); ok {
+		return respErr.Status
+	} else {
+		t.Fatalf("response body was neither a valid response nor an error response: %v", err)
+		return 0
+	}
+}
+
+func TestServeHTTPMalformedRequest(t *testing.T) {
+	rs := NewResponder(stubSource{})
+
+	r := httptest.NewRequest(http.MethodGet, "/not-valid-base64!!!", nil)
+	w := httptest.NewRecorder()
+	rs.ServeHTTP(w, r)
+
+	if got := responseStatus(t, w); got != ocsp.Malformed {
+		t.Errorf("status = %v, want %v", got, ocsp.Malformed)
+	}
+}
+
+func TestServeHTTPUnknownIssuer(t *testing.T) {
+	rs := NewResponder(stubSource{err: errUnknownIssuer})
+
+	w := doRequest(t, rs, http.MethodGet)
+	if got := responseStatus(t, w); got != ocsp.Unauthorized {
+		t.Errorf("status = %v, want %v", got, ocsp.Unauthorized)
+	}
+}
+
+func TestServeHTTPTransientFailure(t *testing.T) {
+	rs := NewResponder(stubSource{err: errors.New("signing backend unavailable")})
+
+	w := doRequest(t, rs, http.MethodGet)
+	if got := responseStatus(t, w); got != ocsp.TryLater {
+		t.Errorf("status = %v, want %v", got, ocsp.TryLater)
+	}
+}
+
+func TestServeHTTPSuccessAndCache(t *testing.T) {
+	der := signTestResponse(t)
+	source := &countingSource{stubSource: stubSource{der: der}}
+	rs := NewResponder(source)
+
+	for i := 0; i < 2; i++ {
+		w := doRequest(t, rs, http.MethodPost)
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: status code = %d, want 200", i, w.Code)
+		}
+		if got := responseStatus(t, w); got != ocsp.Success {
+			t.Fatalf("request %d: status = %v, want success", i, got)
+		}
+	}
+
+	if source.calls != 1 {
+		t.Errorf("Source.Response was called %d times, want 1 (the second request should hit the cache)", source.calls)
+	}
+}
+
+func TestRequestDERRejectsUnsupportedMethod(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPut, "/", nil)
+	if _, ok := requestDER(r); ok {
+		t.Fatal("requestDER accepted a PUT request")
+	}
+}
+
+func TestRequestDERRejectsWrongContentType(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(testRequest(t)))
+	r.Header.Set("Content-Type", "text/plain")
+	if _, ok := requestDER(r); ok {
+		t.Fatal("requestDER accepted a POST with the wrong Content-Type")
+	}
+}
+
+func TestRequestCacheKeyDiffersBySerial(t *testing.T) {
+	reqA := &ocsp.Request{IssuerKeyHash: []byte("hash"), SerialNumber: big.NewInt(1)}
+	reqB := &ocsp.Request{IssuerKeyHash: []byte("hash"), SerialNumber: big.NewInt(2)}
+
+	if requestCacheKey(reqA) == requestCacheKey(reqB) {
+		t.Fatal("requestCacheKey collided for requests with different serial numbers")
+	}
+}
+
+// signTestResponse builds a DER-encoded, self-signed OCSP "good" response
+// for a throwaway certificate, so tests can exercise a real success path
+// through Responder without standing up a full signer.
+func signTestResponse(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate a test key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test responder"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create a test certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatalf("failed to parse the test certificate: %v", err)
+	}
+
+	resp := ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: big.NewInt(1),
+		ThisUpdate:   time.Now().Round(time.Hour),
+		NextUpdate:   time.Now().Round(time.Hour).Add(time.Hour),
+		Certificate:  cert,
+	}
+
+	der, err := ocsp.CreateResponse(cert, cert, resp, key)
+	if err != nil {
+		t.Fatalf("failed to sign a test OCSP response: %v", err)
+	}
+	return der
+}
@@ -0,0 +1,108 @@
+package ocsp
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestParseRevocationFieldWithReason(t *testing.T) {
+	entry, err := parseRevocationField("260101120000Z,keyCompromise")
+	if err != nil {
+		t.Fatalf("parseRevocationField returned an error: %v", err)
+	}
+	if entry.status != "revoked" {
+		t.Errorf("status = %q, want revoked", entry.status)
+	}
+	if entry.reason != ocsp.KeyCompromise {
+		t.Errorf("reason = %d, want %d", entry.reason, ocsp.KeyCompromise)
+	}
+	want := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if !entry.revokedAt.Equal(want) {
+		t.Errorf("revokedAt = %v, want %v", entry.revokedAt, want)
+	}
+}
+
+func TestParseRevocationFieldWithoutReason(t *testing.T) {
+	entry, err := parseRevocationField("260101120000Z")
+	if err != nil {
+		t.Fatalf("parseRevocationField returned an error: %v", err)
+	}
+	if entry.status != "revoked" {
+		t.Errorf("status = %q, want revoked", entry.status)
+	}
+	if entry.reason != 0 {
+		t.Errorf("reason = %d, want 0 (unspecified)", entry.reason)
+	}
+}
+
+func TestParseRevocationFieldInvalidDate(t *testing.T) {
+	if _, err := parseRevocationField("not-a-date"); err == nil {
+		t.Fatal("expected an error for a malformed revocation date, got nil")
+	}
+}
+
+func TestParseRevocationFieldInvalidReason(t *testing.T) {
+	if _, err := parseRevocationField("260101120000Z,notAReason"); err == nil {
+		t.Fatal("expected an error for an unknown revocation reason, got nil")
+	}
+}
+
+func TestParseIndexTxt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.txt")
+	contents := "" +
+		"V\t270101000000Z\t\t01\tunknown\t/CN=good\n" +
+		"E\t260101000000Z\t\t02\tunknown\t/CN=expired\n" +
+		"R\t270101000000Z\t260601000000Z,keyCompromise\t03\tunknown\t/CN=revoked\n"
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := parseIndexTxt(path)
+	if err != nil {
+		t.Fatalf("parseIndexTxt returned an error: %v", err)
+	}
+
+	if got := entries["01"].status; got != "good" {
+		t.Errorf("V row: status = %q, want good", got)
+	}
+	if got := entries["02"].status; got != "good" {
+		t.Errorf("E row: status = %q, want good (expired is still good per RFC 6960 2.2)", got)
+	}
+	if got := entries["03"].status; got != "revoked" {
+		t.Errorf("R row: status = %q, want revoked", got)
+	}
+	if got := entries["03"].reason; got != ocsp.KeyCompromise {
+		t.Errorf("R row: reason = %d, want %d", got, ocsp.KeyCompromise)
+	}
+}
+
+// TestIndexTxtSourceSerialPadding guards against a mismatch between
+// OpenSSL's always-even-length hex serials and math/big's unpadded
+// rendering: a certificate whose serial's top nibble is zero must still
+// resolve to the index.txt row OpenSSL wrote for it.
+func TestIndexTxtSourceSerialPadding(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.txt")
+	contents := "V\t270101000000Z\t\t0A\tunknown\t/CN=leaf\n"
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	source := NewIndexTxtSource(path)
+	issuer := &x509.Certificate{SubjectKeyId: []byte{0x01}}
+
+	status, _, _, err := source.Lookup(big.NewInt(0x0A), issuer)
+	if err != nil {
+		t.Fatalf("Lookup failed for a serial whose unpadded hex is shorter than index.txt's: %v", err)
+	}
+	if status != "good" {
+		t.Errorf("status = %q, want good", status)
+	}
+}
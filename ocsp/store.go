@@ -0,0 +1,75 @@
+package ocsp
+
+import (
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sync"
+
+	cferr "github.com/cloudflare/cfssl/errors"
+)
+
+// DirectoryStore is a ResponseStore that writes one DER file per
+// response into a directory, named by the issuer's key hash and the
+// certificate's serial number.
+type DirectoryStore struct {
+	Dir string
+}
+
+// Put implements ResponseStore.
+func (d DirectoryStore) Put(issuer *x509.Certificate, serial *big.Int, der []byte) error {
+	if err := ioutil.WriteFile(d.path(issuer, serial), der, 0644); err != nil {
+		return cferr.Wrap(cferr.OCSPError, cferr.Unknown, err)
+	}
+	return nil
+}
+
+// Get implements ResponseStore.
+func (d DirectoryStore) Get(issuer *x509.Certificate, serial *big.Int) ([]byte, error) {
+	der, err := ioutil.ReadFile(d.path(issuer, serial))
+	if err != nil {
+		return nil, cferr.Wrap(cferr.OCSPError, cferr.ReadFailed, err)
+	}
+	return der, nil
+}
+
+func (d DirectoryStore) path(issuer *x509.Certificate, serial *big.Int) string {
+	name := fmt.Sprintf("%s-%s.der", hex.EncodeToString([]byte(keyHash(issuer))), serial.String())
+	return filepath.Join(d.Dir, name)
+}
+
+// MapStore is an in-memory, concurrency-safe ResponseStore, useful for
+// tests and for fronting a DirectoryStore with a cache.
+type MapStore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// NewMapStore constructs an empty MapStore.
+func NewMapStore() *MapStore {
+	return &MapStore{data: make(map[string][]byte)}
+}
+
+// Put implements ResponseStore.
+func (m *MapStore) Put(issuer *x509.Certificate, serial *big.Int, der []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.data[responseKey(issuer, serial)] = der
+	return nil
+}
+
+// Get implements ResponseStore.
+func (m *MapStore) Get(issuer *x509.Certificate, serial *big.Int) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	der, ok := m.data[responseKey(issuer, serial)]
+	if !ok {
+		return nil, cferr.New(cferr.OCSPError, cferr.IssuerMismatch)
+	}
+	return der, nil
+}